@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	repository string
+	debug      bool
+	logger     *logrus.Logger
+	pr         = print{}
+	store      Storage
+)
+
+// rootCmd is the mementor entry point. With no subcommand it behaves like
+// `mementor fetch`, matching the pre-cobra default.
+var rootCmd = &cobra.Command{
+	Use:           "mementor",
+	Short:         "Display, add and remove mementos.",
+	Version:       version,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		logger = logrus.New()
+		if debug {
+			logger.Level = logrus.DebugLevel
+		} else {
+			logger.Level = logrus.InfoLevel
+		}
+		logger.Formatter = new(logrus.TextFormatter)
+
+		var err error
+		store, err = openStorage(repository)
+		return err
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fetch()
+	},
+}
+
+var cmdAdd = &cobra.Command{
+	Use:   "add [message...]",
+	Short: "Add new memento.",
+	Long: `Add new memento. Tokens prefixed with "+" (e.g. "+work") are pulled
+out of the message and stored as tags instead, same as any tags given via
+--tag.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return add(args)
+	},
+}
+
+var cmdFetch = &cobra.Command{
+	Use:   "fetch",
+	Short: "Display a random memento.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fetch()
+	},
+}
+
+var cmdRemove = &cobra.Command{
+	Use:     "rm <id>",
+	Aliases: []string{"del"},
+	Short:   "Remove a memento.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return remove(args)
+	},
+}
+
+var cmdModify = &cobra.Command{
+	Use:     "mod <id> [property:value]",
+	Aliases: []string{"modify"},
+	Short:   "Modify an existing memento.",
+	Long: `Modify an existing memento. --priority and --message supersede the
+legacy "mementor mod 123 priority:3" micro-syntax, which is kept working
+for backwards compatibility.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return modify(args)
+	},
+}
+
+var cmdList = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List all mementos.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return list()
+	},
+}
+
+var cmdInit = &cobra.Command{
+	Use:   "init",
+	Short: "Initialize the repository (use --encrypt for an encrypted one).",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return initRepo()
+	},
+}
+
+var cmdPasswd = &cobra.Command{
+	Use:   "passwd",
+	Short: "Change the password of an encrypted repository.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return passwdCmd()
+	},
+}
+
+var cmdForget = &cobra.Command{
+	Use:   "forget",
+	Short: "Prune mementos per --keep-* retention rules.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return forget()
+	},
+}
+
+var cmdTag = &cobra.Command{
+	Use:   "tag <id> +newtag -- -oldtag",
+	Short: "Add (+tag) or remove (-tag) tags on a memento.",
+	Long: `Add (+tag) or remove (-tag) tags on a memento. "-tag" tokens are
+parsed as command-line flags unless they come after "--", e.g.
+"mementor tag 3 +new -- -old".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tagCmd(args)
+	},
+}
+
+var cmdServe = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the memento store over HTTP (see --addr, --token).",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return serve()
+	},
+}
+
+var cmdVersion = &cobra.Command{
+	Use:   "version",
+	Short: "Display the current version.",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(version)
+	},
+}
+
+var cmdCompletion = &cobra.Command{
+	Use:       "completion [bash|zsh|fish]",
+	Short:     "Generate shell completion scripts.",
+	Args:      cobra.ExactValidArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletion(os.Stdout)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		default:
+			return fmt.Errorf("unsupported shell: %s", args[0])
+		}
+	},
+}
+
+func init() {
+	home := os.Getenv("HOME")
+	if home == "" {
+		panic("HOME variable is not set")
+	}
+	defaultRepository := "file://" + home + "/.mementor/mementos.json"
+	if env := os.Getenv("MEMENTOR_REPOSITORY"); env != "" {
+		defaultRepository = env
+	}
+
+	rootCmd.PersistentFlags().StringVarP(&repository, "repository", "r", defaultRepository, "Repository URL (file://, sqlite:// or s3://). Overrides MEMENTOR_REPOSITORY.")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Turn debugging on.")
+	rootCmd.PersistentFlags().StringVarP(&passwordFlag, "password", "p", "", "Repository password. Overrides MEMENTOR_PASSWORD.")
+
+	cmdAdd.Flags().IntVar(&addPriorityFlag, "priority", 1, "Priority of the new memento.")
+	cmdAdd.Flags().StringArrayVar(&addTagFlags, "tag", nil, "Tag the new memento. Repeatable.")
+
+	cmdFetch.Flags().StringArrayVar(&tagFlags, "tag", nil, "Only consider mementos with this tag. Repeatable; AND semantics.")
+	cmdFetch.Flags().StringArrayVar(&notTagFlags, "not-tag", nil, "Exclude mementos with this tag. Repeatable.")
+
+	cmdList.Flags().StringArrayVar(&tagFlags, "tag", nil, "Only include mementos with this tag. Repeatable; AND semantics.")
+	cmdList.Flags().StringArrayVar(&notTagFlags, "not-tag", nil, "Exclude mementos with this tag. Repeatable.")
+
+	cmdModify.Flags().StringVar(&modifyPriorityFlag, "priority", "", "New priority for the memento.")
+	cmdModify.Flags().StringVar(&modifyMessageFlag, "message", "", "New message for the memento.")
+
+	cmdInit.Flags().BoolVar(&encryptFlag, "encrypt", false, "Encrypt the new repository.")
+
+	cmdForget.Flags().IntVar(&keepLastFlag, "keep-last", 0, "Keep the N most recent mementos.")
+	cmdForget.Flags().StringVar(&keepWithinFlag, "keep-within", "", "Keep mementos created within DURATION (e.g. 30d, 6m, 2h).")
+	cmdForget.Flags().IntVar(&keepPriorityAboveFlag, "keep-priority-above", 0, "Keep mementos with Priority above P.")
+	cmdForget.Flags().StringVar(&olderThanFlag, "older-than", "", "Only forget mementos older than DURATION.")
+	cmdForget.Flags().BoolVar(&forgetDryRunFlag, "dry-run", false, "Show what would be removed without writing.")
+	cmdForget.Flags().BoolVar(&forgetPruneFlag, "prune", false, "Also compact the repository after forgetting.")
+
+	cmdServe.Flags().StringVar(&addrFlag, "addr", ":8080", "Address to listen on.")
+	cmdServe.Flags().StringVar(&tokenFlag, "token", "", "Bearer token required for mutating routes.")
+
+	rootCmd.AddCommand(cmdAdd, cmdFetch, cmdRemove, cmdModify, cmdList, cmdInit, cmdPasswd, cmdForget, cmdTag, cmdServe, cmdVersion, cmdCompletion)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		pr.error(err.Error())
+	}
+}
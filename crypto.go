@@ -0,0 +1,342 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// encryptedMagic prefixes an encrypted mementos file so readMementos can
+// tell an encrypted repository apart from a plain JSON one.
+const encryptedMagic = "mementor-enc\x00"
+
+const (
+	scryptN      = 32768
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+	masterKeyLen = 32
+)
+
+// keys is the on-disk format of the `<datafile>.keys` sidecar: the master
+// key encrypted under a password-derived key, plus everything needed to
+// re-derive that key.
+type keys struct {
+	Salt       []byte `json:"salt"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// cachedPassword holds the password for the lifetime of this process once
+// it has been supplied via -p, MEMENTOR_PASSWORD or an interactive prompt,
+// so the user is only asked once per invocation.
+var cachedPassword string
+
+// passwordFlag lets --password seed cachedPassword; see the root command's
+// persistent flags in cli.go.
+var passwordFlag string
+
+// encryptFlag switches `mementor init` into encrypted-repository mode.
+var encryptFlag bool
+
+// password returns the cached repository password if one is set; otherwise
+// it resolves it in order from --password, MEMENTOR_PASSWORD, or a fresh
+// interactive prompt, and caches the result.
+func password() (string, error) {
+	if cachedPassword != "" {
+		return cachedPassword, nil
+	}
+	if passwordFlag != "" {
+		cachedPassword = passwordFlag
+		return cachedPassword, nil
+	}
+	if env := os.Getenv("MEMENTOR_PASSWORD"); env != "" {
+		cachedPassword = env
+		return cachedPassword, nil
+	}
+	fmt.Print("Repository password: ")
+	b, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	cachedPassword = string(b)
+	return cachedPassword, nil
+}
+
+// deriveKey stretches a password into a scrypt-derived key of scryptKeyLen
+// bytes, using the given salt and cost parameters.
+func deriveKey(pw string, salt []byte, n, r, p int) ([]byte, error) {
+	return scrypt.Key([]byte(pw), salt, n, r, p, scryptKeyLen)
+}
+
+// newMasterKey generates a fresh random master key.
+func newMasterKey() ([]byte, error) {
+	mk := make([]byte, masterKeyLen)
+	if _, err := io.ReadFull(rand.Reader, mk); err != nil {
+		return nil, err
+	}
+	return mk, nil
+}
+
+// sealMasterKey encrypts masterKey under a password-derived key, returning
+// the keys blob to write to the `.keys` sidecar.
+func sealMasterKey(pw string, masterKey []byte) (*keys, error) {
+	salt := make([]byte, saltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	derived, err := deriveKey(pw, salt, scryptN, scryptR, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := gcm.Seal(nil, nonce, masterKey, nil)
+	return &keys{
+		Salt:       salt,
+		N:          scryptN,
+		R:          scryptR,
+		P:          scryptP,
+		Nonce:      nonce,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// openMasterKey decrypts the master key from a keys blob using pw. It
+// returns an error (via the GCM auth tag check) for a wrong password or a
+// tampered keys file.
+func openMasterKey(pw string, k *keys) ([]byte, error) {
+	derived, err := deriveKey(pw, k.Salt, k.N, k.R, k.P)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := gcm.Open(nil, k.Nonce, k.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("Wrong password or corrupted keys file")
+	}
+	return masterKey, nil
+}
+
+// loadKeys reads and parses the `.keys` sidecar for path.
+func loadKeys(path string) (*keys, error) {
+	data, err := ioutil.ReadFile(keysPath(path))
+	if err != nil {
+		return nil, err
+	}
+	var k keys
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// saveKeys writes the `.keys` sidecar for path.
+func saveKeys(path string, k *keys) error {
+	data, err := json.Marshal(k)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(keysPath(path), data, 0600)
+}
+
+// keysPath returns the sidecar keys file path for a repository data file.
+func keysPath(path string) string {
+	return path + ".keys"
+}
+
+// isEncryptedRepository reports whether a repository at path has been
+// initialized with `mementor init --encrypt`.
+func isEncryptedRepository(path string) bool {
+	_, err := os.Stat(keysPath(path))
+	return err == nil
+}
+
+// encryptPayload seals plaintext under masterKey with a fresh nonce,
+// prepending encryptedMagic and the nonce so the result can be written
+// directly to the data file.
+func encryptPayload(masterKey, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	out := make([]byte, 0, len(encryptedMagic)+len(nonce)+len(ciphertext))
+	out = append(out, []byte(encryptedMagic)...)
+	out = append(out, nonce...)
+	out = append(out, ciphertext...)
+	return out, nil
+}
+
+// decryptPayload reverses encryptPayload. data must start with
+// encryptedMagic; the GCM auth tag rejects any tampering.
+func decryptPayload(masterKey, data []byte) ([]byte, error) {
+	if len(data) < len(encryptedMagic) || string(data[:len(encryptedMagic)]) != encryptedMagic {
+		return nil, errors.New("Not an encrypted mementor repository")
+	}
+	data = data[len(encryptedMagic):]
+
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, errors.New("Corrupted encrypted repository")
+	}
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("Failed to decrypt repository: wrong password or tampered data")
+	}
+	return plaintext, nil
+}
+
+// initRepo implements `mementor init`. With --encrypt it prompts for a
+// password, derives a key, generates a master key and writes the `.keys`
+// sidecar before creating an empty encrypted repository.
+func initRepo() error {
+	fs, ok := store.(*FileStorage)
+	if !ok {
+		return errors.New("init --encrypt is only supported for file:// repositories")
+	}
+	if !encryptFlag {
+		_, err := fs.createFile()
+		return err
+	}
+
+	if isEncryptedRepository(fs.path) {
+		return fmt.Errorf("%s is already an encrypted repository", fs.path)
+	}
+
+	fmt.Print("New repository password: ")
+	pw1, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	fmt.Print("Confirm password: ")
+	pw2, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	if string(pw1) != string(pw2) {
+		return errors.New("Passwords do not match")
+	}
+
+	masterKey, err := newMasterKey()
+	if err != nil {
+		return err
+	}
+	k, err := sealMasterKey(string(pw1), masterKey)
+	if err != nil {
+		return err
+	}
+	if err := saveKeys(fs.path, k); err != nil {
+		return err
+	}
+	cachedPassword = string(pw1)
+
+	if err := fs.Save(nil); err != nil {
+		return err
+	}
+	pr.info("Initialized encrypted repository at %s", fs.path)
+	return nil
+}
+
+// passwdCmd implements `mementor passwd`: it re-encrypts the master key
+// under a new password without touching the mementos payload.
+func passwdCmd() error {
+	fs, ok := store.(*FileStorage)
+	if !ok {
+		return errors.New("passwd is only supported for file:// repositories")
+	}
+	if !isEncryptedRepository(fs.path) {
+		return errors.New("Repository is not encrypted")
+	}
+
+	k, err := loadKeys(fs.path)
+	if err != nil {
+		return err
+	}
+	oldPw, err := password()
+	if err != nil {
+		return err
+	}
+	masterKey, err := openMasterKey(oldPw, k)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print("New repository password: ")
+	pw1, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	fmt.Print("Confirm password: ")
+	pw2, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return err
+	}
+	if string(pw1) != string(pw2) {
+		return errors.New("Passwords do not match")
+	}
+
+	newKeys, err := sealMasterKey(string(pw1), masterKey)
+	if err != nil {
+		return err
+	}
+	if err := saveKeys(fs.path, newKeys); err != nil {
+		return err
+	}
+	cachedPassword = string(pw1)
+	pr.info("Password changed")
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+// TestOpenMasterKeyWrongPassword verifies that a wrong password is rejected
+// by the GCM auth tag check in openMasterKey, rather than silently
+// returning a garbage key.
+func TestOpenMasterKeyWrongPassword(t *testing.T) {
+	masterKey, err := newMasterKey()
+	if err != nil {
+		t.Fatalf("newMasterKey: %v", err)
+	}
+	k, err := sealMasterKey("correct horse battery staple", masterKey)
+	if err != nil {
+		t.Fatalf("sealMasterKey: %v", err)
+	}
+
+	if _, err := openMasterKey("wrong password", k); err == nil {
+		t.Fatal("expected an error opening the master key with the wrong password, got nil")
+	}
+}
+
+// TestDecryptPayloadDetectsTampering verifies that flipping a single byte
+// of an encrypted payload makes decryptPayload fail on the GCM auth tag
+// instead of returning corrupted plaintext.
+func TestDecryptPayloadDetectsTampering(t *testing.T) {
+	masterKey, err := newMasterKey()
+	if err != nil {
+		t.Fatalf("newMasterKey: %v", err)
+	}
+	ciphertext, err := encryptPayload(masterKey, []byte(`[{"Id":1,"Msg":"hello"}]`))
+	if err != nil {
+		t.Fatalf("encryptPayload: %v", err)
+	}
+
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	if _, err := decryptPayload(masterKey, tampered); err == nil {
+		t.Fatal("expected decryptPayload to reject tampered ciphertext, got nil error")
+	}
+}
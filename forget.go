@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xeonx/timeago"
+)
+
+// flags for forget, set up as cobra flags on its command in cli.go.
+var (
+	keepLastFlag          int
+	keepWithinFlag        string
+	keepPriorityAboveFlag int
+	olderThanFlag         string
+	forgetDryRunFlag      bool
+	forgetPruneFlag       bool
+)
+
+// parseRetentionDuration accepts the coarser d(ay)/w(eek)/m(onth)/y(ear)
+// suffixes restic-style retention flags use, plus everything
+// time.ParseDuration does. The single-letter suffixes are checked first:
+// stdlib's "m" means minutes, but retention flags document "m" as months
+// (e.g. --keep-within 6m), so the coarse grammar must win rather than
+// silently falling through to time.ParseDuration.
+func parseRetentionDuration(s string) (time.Duration, error) {
+	if len(s) >= 2 {
+		unit := s[len(s)-1]
+		if n, err := strconv.Atoi(s[:len(s)-1]); err == nil {
+			day := 24 * time.Hour
+			switch unit {
+			case 'd':
+				return time.Duration(n) * day, nil
+			case 'w':
+				return time.Duration(n) * 7 * day, nil
+			case 'm':
+				return time.Duration(n) * 30 * day, nil
+			case 'y':
+				return time.Duration(n) * 365 * day, nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	return 0, fmt.Errorf("Invalid duration: %q", s)
+}
+
+// forget implements `mementor forget`: it prunes mementos that match none
+// of the configured --keep-* rules, modeled on restic's retention flags. A
+// memento is kept if it matches ANY active rule.
+func forget() error {
+	if keepLastFlag <= 0 && keepWithinFlag == "" && keepPriorityAboveFlag <= 0 && olderThanFlag == "" {
+		return errors.New("forget requires at least one of --keep-last, --keep-within, --keep-priority-above or --older-than")
+	}
+
+	mementos, err := readMementos()
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[int]bool)
+
+	if keepLastFlag > 0 {
+		byRecency := append([]*Memento(nil), mementos...)
+		sort.Slice(byRecency, func(i, j int) bool { return byRecency[i].Time > byRecency[j].Time })
+		for i, m := range byRecency {
+			if i >= keepLastFlag {
+				break
+			}
+			keep[m.Id] = true
+		}
+	}
+
+	if keepWithinFlag != "" {
+		d, err := parseRetentionDuration(keepWithinFlag)
+		if err != nil {
+			return err
+		}
+		cutoff := time.Now().Add(-d)
+		for _, m := range mementos {
+			if time.Unix(m.Time, 0).After(cutoff) {
+				keep[m.Id] = true
+			}
+		}
+	}
+
+	if keepPriorityAboveFlag > 0 {
+		for _, m := range mementos {
+			if m.Priority > keepPriorityAboveFlag {
+				keep[m.Id] = true
+			}
+		}
+	}
+
+	if olderThanFlag != "" {
+		// --older-than is a "keep" rule like the others (a memento is kept
+		// if it matches ANY active rule), so it ends up protecting the same
+		// recent mementos --keep-within does. It's kept as its own flag for
+		// restic-style naming symmetry even though it is currently
+		// equivalent to --keep-within.
+		d, err := parseRetentionDuration(olderThanFlag)
+		if err != nil {
+			return err
+		}
+		cutoff := time.Now().Add(-d)
+		for _, m := range mementos {
+			if time.Unix(m.Time, 0).After(cutoff) {
+				keep[m.Id] = true
+			}
+		}
+	}
+
+	var kept, removed []*Memento
+	for _, m := range mementos {
+		if keep[m.Id] {
+			kept = append(kept, m)
+		} else {
+			removed = append(removed, m)
+		}
+	}
+
+	if forgetDryRunFlag {
+		printMementos(removed, "Would remove")
+		return nil
+	}
+
+	if err := writeMementos(kept); err != nil {
+		return err
+	}
+	printMementos(removed, "Removed")
+
+	if forgetPruneFlag {
+		if v, ok := store.(interface{ Vacuum() error }); ok {
+			if err := v.Vacuum(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// printMementos renders mementos with the same columns as `list`, followed
+// by a summary line that starts with heading (e.g. "Removed 3 mementos.").
+func printMementos(mementos []*Memento, heading string) {
+	pr.underscore(" ID   Age         Pri  Tags            Description")
+	cfg := timeago.NoMax(timeago.English)
+	cfg.PastSuffix = ""
+	for _, m := range mementos {
+		t := time.Unix(m.Time, 0)
+		fmt.Printf("%3d   %10s  %3d  %-14s  %s\n", m.Id, cfg.Format(t), m.Priority, strings.Join(m.Tags, ","), m.Msg)
+	}
+	pr.info("\n%s %d mementos.\n", strings.TrimSpace(heading), len(mementos))
+}
@@ -13,21 +13,15 @@
 package main
 
 import (
-	"encoding/json"
 	"errors"
-	"flag"
 	"fmt"
-	"io"
 	"math/rand"
-	"os"
-	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/Sirupsen/logrus"
 	"github.com/xeonx/timeago"
 )
 
@@ -37,6 +31,10 @@ type Memento struct {
 	Msg      string
 	Time     int64
 	Priority int
+	// Tags is absent from data files written before tag support was
+	// added; json.Unmarshal leaves it nil in that case, which list,
+	// fetch and tag all treat as "no tags".
+	Tags []string
 }
 
 const (
@@ -46,6 +44,16 @@ const (
 type print struct {
 }
 
+// flags for add and modify, set up as cobra flags on their respective
+// commands in cli.go.
+var (
+	addPriorityFlag int
+	addTagFlags     []string
+
+	modifyPriorityFlag string
+	modifyMessageFlag  string
+)
+
 func (p *print) info(msg string, args ...interface{}) {
 	fmt.Printf("\x1b[36;1m"+msg+"\n\x1b[0m", args...)
 }
@@ -58,156 +66,55 @@ func (p *print) underscore(msg string, args ...interface{}) {
 	fmt.Printf("\x1b[4;1m"+msg+"\n\x1b[0m", args...)
 }
 
-var (
-	dataFile *string
-	debug    = flag.Bool("debug", false, "Turn debugging on.")
-	logger   *logrus.Logger
-	pr       = print{}
-)
-
-func init() {
-	home := os.Getenv("HOME")
-	if home == "" {
-		panic("HOME variable is not set")
-	}
-
-	dataFile = flag.String("f", home+"/.mementor/mementos.json", "Path to the mementos storage file.")
-	// parse flags
-	flag.Parse()
-	logger = logrus.New()
-	if *debug {
-		logger.Level = logrus.DebugLevel
-	} else {
-		logger.Level = logrus.InfoLevel
-	}
-	formatter := new(logrus.TextFormatter)
-	//formatter.FullTimestamp = true
-	//formatter.TimestampFormat = "2006-01-02 15:04:05.000"
-	logger.Formatter = formatter
-
-	// create the mementos file if it does not exist
-	if _, err := os.Stat(*dataFile); err != nil {
-		if os.IsNotExist(err) {
-			_, err = createFile()
-			if err != nil {
-				panic("Failed to create data file: " + *dataFile)
-			}
-			pr.info("%s was be created", *dataFile)
-
-		} else {
-			panic(err)
-		}
-	}
-
-}
-
-func main() {
-	args := flag.Args()
-
-	var command string
-	if len(args) > 0 {
-		command = args[0]
-	} else {
-		command = "fetch"
-	}
-	var err error
-	switch command {
-	case "add":
-		err = add()
-	case "fetch":
-		fetch()
-	case "rm", "del":
-		err = remove()
-	case "modify", "mod":
-		err = modify()
-	case "list", "ls":
-		err = list()
-	case "version":
-		fmt.Println(version)
-	case "help":
-		help()
-	default:
-		pr.error("Action `%s` is invalid", command)
-		help()
-	}
-	if err != nil {
-		pr.error(err.Error())
-	}
-
-	return
-}
-
-// print help screen
-func help() {
-	usage := `
-Usage: mementor [OPTIONS...] ACTION [arguments...]
-
-ACTIONS
-	add		Add new memento.
-	fetch		Display a random memento.
-	modify		Modify an existing memento.
-	rm		Remove a memento.
-	help		Display this help.
-	list		List all mementos.
-	version		Display the current version.
-
-OPTIONS
-`
-	fmt.Print(usage)
-	flag.PrintDefaults()
-}
-
-// list all mementos
+// list all mementos, optionally narrowed down by --tag/--not-tag
 // TODO color lines according to their priority
 func list() error {
 	mementos, err := readMementos()
 	if err != nil {
 		return err
 	}
+	mementos = filterByTags(mementos)
 
-	pr.underscore(" ID   Age         Pri  Description")
+	pr.underscore(" ID   Age         Pri  Tags            Description")
 	cfg := timeago.NoMax(timeago.English)
 	cfg.PastSuffix = ""
 	for _, m := range mementos {
 		t := time.Unix(m.Time, 0)
-		fmt.Printf("%3d   %10s  %3d  %s\n", m.Id, cfg.Format(t), m.Priority, m.Msg)
+		fmt.Printf("%3d   %10s  %3d  %-14s  %s\n", m.Id, cfg.Format(t), m.Priority, strings.Join(m.Tags, ","), m.Msg)
 	}
 
 	pr.info("\n%d mementos total.\n", len(mementos))
 	return nil
 }
 
-// print a single random memento message
-// TODO higher priority items should be fetched more often
+// print a single random memento message, weighted by Priority so that
+// higher-priority mementos are fetched more often. --tag/--not-tag narrow
+// the pool that gets sampled from.
 func fetch() (err error) {
 	var n int
 	mementos, err := readMementos()
 	if err != nil {
 		return err
 	}
+	mementos = filterByTags(mementos)
 	if len(mementos) < 1 {
 		return
 	} else {
-		rand.Seed(time.Now().Unix())
-		n = rand.Intn(len(mementos))
+		rand.Seed(time.Now().UnixNano())
+		n = weightedIndex(mementos)
 	}
 	fmt.Println(mementos[n].Msg)
 	return
 }
 
-// modifies an existing memento
-// Example:
-// mementor mod 123 priority:3
-func modify() error {
-	var args []string = flag.Args()
-	if len(args) < 3 {
-		return errors.New("Not enough arguments")
-	}
-	id, err := parseId()
+// modifies an existing memento. args[0] is the memento id. --priority and
+// --message supersede the legacy "mementor mod 123 priority:3" micro-syntax
+// in args[1], which is kept working for backwards compatibility.
+func modify(args []string) error {
+	id, err := parseId(args)
 	if err != nil {
 		return err
 	}
-	// read all mementos
 	mementos, err := readMementos()
 	if err != nil {
 		return err
@@ -218,7 +125,28 @@ func modify() error {
 	}
 	logger.Debugf("found memento at %d", n)
 	m := mementos[n]
-	mod := strings.Split(args[2], ":")
+
+	changed := false
+	if modifyPriorityFlag != "" {
+		value, err := strconv.ParseInt(modifyPriorityFlag, 10, 0)
+		if err != nil {
+			return fmt.Errorf("Not a number: %v", modifyPriorityFlag)
+		}
+		m.Priority = int(value)
+		changed = true
+	}
+	if modifyMessageFlag != "" {
+		m.Msg = modifyMessageFlag
+		changed = true
+	}
+	if changed {
+		return writeMementos(mementos)
+	}
+
+	if len(args) < 2 {
+		return errors.New("Not enough arguments")
+	}
+	mod := strings.Split(args[1], ":")
 	if len(mod) < 2 {
 		return fmt.Errorf("Your modification must be in the form of property:value")
 	}
@@ -232,31 +160,29 @@ func modify() error {
 			return fmt.Errorf("Not a number: %v", mod[1])
 		}
 		m.Priority = int(value)
-		if err := writeMementos(mementos); err != nil {
-			return err
-		}
 	case reMsg.MatchString(mod[0]):
-		m.Msg = mod[0]
-		if err := writeMementos(mementos); err != nil {
-			return err
-		}
-
+		m.Msg = mod[1]
 	default:
 		return fmt.Errorf("You are trying to modify invalid property: %s", mod[0])
 	}
-
-	return nil
+	return writeMementos(mementos)
 }
 
-// add a new memento to the stack
-func add() error {
-	var args []string = flag.Args()
-	if len(args) < 2 {
+// add a new memento to the stack. Tokens prefixed with "+" (e.g. "+work")
+// are pulled out of the message and stored as tags instead, same as any
+// tags given via --tag.
+func add(args []string) error {
+	if len(args) < 1 {
 		return errors.New("Please specify the message.")
 	}
+	words, tags := splitTags(args)
+	tags = append(tags, addTagFlags...)
 	// concat the remaining arguments as a message string
-	msg := strings.Join(args[1:], " ")
+	msg := strings.Join(words, " ")
 	mementos, err := readMementos()
+	if err != nil {
+		return err
+	}
 	var lastId int
 	if len(mementos) < 1 {
 		lastId = 0
@@ -268,25 +194,21 @@ func add() error {
 		Id:       lastId + 1,
 		Msg:      msg,
 		Time:     time.Now().Unix(),
-		Priority: 1,
+		Priority: addPriorityFlag,
+		Tags:     tags,
 	}
 	logger.Debugf("Writing %+v", m)
-	if err != nil {
-		return err
-	}
 	mementos = append(mementos, &m)
-	err = writeMementos(mementos)
-	return err
+	return writeMementos(mementos)
 }
 
-// remove a memento from the stack
-func remove() error {
-	id, err := parseId()
+// remove a memento from the stack. args[0] is the memento id.
+func remove(args []string) error {
+	id, err := parseId(args)
 	if err != nil {
 		return err
 	}
 
-	// read all mementos
 	mementos, err := readMementos()
 	if err != nil {
 		return err
@@ -301,60 +223,22 @@ func remove() error {
 	before := mementos[:n]
 	after := mementos[n+1:]
 	mementos = append(before, after...)
-	writeMementos(mementos)
-	return nil
+	return writeMementos(mementos)
 }
 
-// return parsed mementos from the passed file
-//func readMementos() ([]*Memento, error) {
+// readMementos loads all mementos from the configured repository.
 func readMementos() ([]*Memento, error) {
-	logger.Debugf("opening %s", *dataFile)
-	r, err := os.Open(*dataFile)
-	if err != nil {
-		return nil, err
-	}
-	dec := json.NewDecoder(r)
-	var mementos []*Memento
-	if err := dec.Decode(&mementos); err != nil && err != io.EOF {
-		return nil, err
-	}
-	return mementos, nil
+	return store.Load()
 }
 
-// write mementos into the file as a JSON string
-func writeMementos(mementos []*Memento) (err error) {
-	var file *os.File
-	// truncate the file
-	file, err = createFile()
-	if err != nil {
-		return err
-	}
-
-	s, err := json.Marshal(mementos)
-	if err != nil {
+// writeMementos persists mementos to the configured repository, holding the
+// repository lock for the duration of the write.
+func writeMementos(mementos []*Memento) error {
+	if err := store.Lock(); err != nil {
 		return err
 	}
-	written, err := file.Write(s)
-	fmt.Printf("%d bytes written\n", written)
-	return
-}
-
-// creates an empty file or truncates an existing file
-func createFile() (file *os.File, err error) {
-	// create directory if necessary
-	dir := filepath.Dir(*dataFile)
-	if _, err = os.Stat(dir); err != nil {
-		fmt.Printf("Creating directory %s\n", dir)
-		err = os.MkdirAll(dir, os.ModeDir|0700)
-		if err != nil {
-			return nil, fmt.Errorf("Failed to create directory for the data file at %s.\n%s", dir, err)
-		}
-	}
-	file, err = os.Create(*dataFile)
-	if err != nil {
-		return nil, err
-	}
-	return file, nil
+	defer store.Unlock()
+	return store.Save(mementos)
 }
 
 // returns index at which the specified memento is. The
@@ -371,16 +255,14 @@ func findMementoById(mementos []*Memento, id int) (int, bool) {
 	return 0, false
 }
 
-// parses arguments, retrieves an ID
-func parseId() (int, error) {
-	var args []string = flag.Args()
-	if len(args) < 2 {
+// parseId reads the memento id out of args[0].
+func parseId(args []string) (int, error) {
+	if len(args) < 1 {
 		return 0, errors.New("Missing memento Id in arguments")
 	}
-	id, err := strconv.ParseInt(args[1], 10, 0)
+	id, err := strconv.ParseInt(args[0], 10, 0)
 	if err != nil || id < 0 {
-		return 0, fmt.Errorf("Invalid memento Id: %v", args[1])
+		return 0, fmt.Errorf("Invalid memento Id: %v", args[0])
 	}
 	return int(id), nil
-
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// weight returns the sampling weight for a memento's priority. Priorities
+// that are zero or negative are clamped to 1 so every memento stays
+// reachable by fetch.
+func weight(priority int) int {
+	if priority < 1 {
+		return 1
+	}
+	return priority
+}
+
+// cumulativeWeights builds a running-sum array of the (clamped) priority of
+// each memento, e.g. priorities [1, 3, 2] become [1, 4, 6]. The total weight
+// is the last entry.
+func cumulativeWeights(mementos []*Memento) []int {
+	sums := make([]int, len(mementos))
+	total := 0
+	for i, m := range mementos {
+		total += weight(m.Priority)
+		sums[i] = total
+	}
+	return sums
+}
+
+// weightedIndex picks a random index into mementos, biased towards higher
+// Priority values. It runs in O(log n) thanks to sort.SearchInts over the
+// cumulative weight array.
+func weightedIndex(mementos []*Memento) int {
+	sums := cumulativeWeights(mementos)
+	total := sums[len(sums)-1]
+	draw := rand.Intn(total)
+	return sort.SearchInts(sums, draw+1)
+}
+
+// AliasTable is a Vose alias method sampler: after an O(n) build, it draws a
+// weighted-random index in O(1), which matters once `fetch --count N` needs
+// to take many samples from the same memento set.
+type AliasTable struct {
+	prob  []float64
+	alias []int
+}
+
+// NewAliasTable builds the alias table for the given mementos' priorities.
+func NewAliasTable(mementos []*Memento) *AliasTable {
+	n := len(mementos)
+	scaled := make([]float64, n)
+	total := 0
+	for _, m := range mementos {
+		total += weight(m.Priority)
+	}
+	for i, m := range mementos {
+		scaled[i] = float64(weight(m.Priority)) * float64(n) / float64(total)
+	}
+
+	prob := make([]float64, n)
+	alias := make([]int, n)
+
+	var small, large []int
+	for i, p := range scaled {
+		if p < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		prob[s] = scaled[s]
+		alias[s] = l
+
+		scaled[l] = scaled[l] + scaled[s] - 1
+		if scaled[l] < 1 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+	for len(large) > 0 {
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+		prob[l] = 1
+	}
+	for len(small) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		prob[s] = 1
+	}
+
+	return &AliasTable{prob: prob, alias: alias}
+}
+
+// Sample draws a weighted-random index in O(1).
+func (t *AliasTable) Sample() int {
+	n := len(t.prob)
+	i := rand.Intn(n)
+	if rand.Float64() < t.prob[i] {
+		return i
+	}
+	return t.alias[i]
+}
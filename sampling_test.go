@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// sampleFrequencies draws n times using draw and returns the relative
+// frequency with which each of the k indices was chosen.
+func sampleFrequencies(n, k int, draw func() int) []float64 {
+	counts := make([]int, k)
+	for i := 0; i < n; i++ {
+		counts[draw()]++
+	}
+	freqs := make([]float64, k)
+	for i, c := range counts {
+		freqs[i] = float64(c) / float64(n)
+	}
+	return freqs
+}
+
+// TestWeightedIndexMatchesExpectedFrequencies verifies that weightedIndex
+// samples mementos proportionally to their (clamped) Priority.
+func TestWeightedIndexMatchesExpectedFrequencies(t *testing.T) {
+	mementos := []*Memento{
+		{Id: 1, Priority: 1},
+		{Id: 2, Priority: 3},
+		{Id: 3, Priority: 2},
+	}
+	total := 0
+	for _, m := range mementos {
+		total += weight(m.Priority)
+	}
+
+	const n = 200000
+	const tolerance = 0.01
+	freqs := sampleFrequencies(n, len(mementos), func() int {
+		return weightedIndex(mementos)
+	})
+	for i, m := range mementos {
+		expected := float64(weight(m.Priority)) / float64(total)
+		if math.Abs(freqs[i]-expected) > tolerance {
+			t.Errorf("memento %d: expected frequency %.4f, got %.4f", m.Id, expected, freqs[i])
+		}
+	}
+}
+
+// TestAliasTableSampleMatchesExpectedFrequencies verifies the same property
+// for the O(1) alias-method sampler.
+func TestAliasTableSampleMatchesExpectedFrequencies(t *testing.T) {
+	mementos := []*Memento{
+		{Id: 1, Priority: 1},
+		{Id: 2, Priority: 3},
+		{Id: 3, Priority: 2},
+		{Id: 4, Priority: 5},
+	}
+	total := 0
+	for _, m := range mementos {
+		total += weight(m.Priority)
+	}
+
+	table := NewAliasTable(mementos)
+	const n = 200000
+	const tolerance = 0.01
+	freqs := sampleFrequencies(n, len(mementos), table.Sample)
+	for i, m := range mementos {
+		expected := float64(weight(m.Priority)) / float64(total)
+		if math.Abs(freqs[i]-expected) > tolerance {
+			t.Errorf("memento %d: expected frequency %.4f, got %.4f", m.Id, expected, freqs[i])
+		}
+	}
+}
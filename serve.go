@@ -0,0 +1,229 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed static
+var staticFS embed.FS
+
+// flags for serve, set up as cobra flags on its command in cli.go.
+var (
+	addrFlag  string
+	tokenFlag string
+)
+
+// mementoServer keeps an in-memory copy of the mementos, guarded by a
+// RWMutex, and reloads it from the repository whenever a write happens so
+// concurrent CLI use stays consistent with the HTTP view.
+type mementoServer struct {
+	mu       sync.RWMutex
+	mementos []*Memento
+}
+
+func newMementoServer() (*mementoServer, error) {
+	s := &mementoServer{}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *mementoServer) reload() error {
+	mementos, err := readMementos()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.mementos = mementos
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *mementoServer) snapshot() []*Memento {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mementos
+}
+
+// serve implements `mementor serve --addr :8080`: it exposes the memento
+// store over HTTP so a random reminder can be pulled up from a browser or a
+// phone on the LAN.
+func serve() error {
+	s, err := newMementoServer()
+	if err != nil {
+		return err
+	}
+
+	index, err := staticFS.ReadFile("static/index.html")
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(index)
+	})
+	mux.HandleFunc("/api/fetch", s.handleFetch)
+	mux.HandleFunc("/api/mementos", s.handleMementos)
+	mux.HandleFunc("/api/mementos/", s.handleMemento)
+
+	pr.info("Listening on %s", addrFlag)
+	return http.ListenAndServe(addrFlag, mux)
+}
+
+func (s *mementoServer) authorized(r *http.Request) bool {
+	if tokenFlag == "" {
+		return true
+	}
+	return r.Header.Get("Authorization") == "Bearer "+tokenFlag
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// handleFetch serves GET /api/fetch: one weighted-random memento.
+func (s *mementoServer) handleFetch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	mementos := s.snapshot()
+	if len(mementos) < 1 {
+		writeJSON(w, http.StatusOK, Memento{})
+		return
+	}
+	n := weightedIndex(mementos)
+	writeJSON(w, http.StatusOK, mementos[n])
+}
+
+// handleMementos serves GET /api/mementos (list) and POST /api/mementos
+// (add, requires --token if set).
+func (s *mementoServer) handleMementos(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.snapshot())
+	case http.MethodPost:
+		if !s.authorized(r) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var in Memento
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// Hold the write lock across the whole read-modify-write-reload
+		// cycle so two concurrent POSTs can't both compute lastId from the
+		// same stale snapshot and assign duplicate Ids.
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		mementos, err := readMementos()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		lastId := 0
+		if len(mementos) > 0 {
+			lastId = mementos[len(mementos)-1].Id
+		}
+		if in.Priority == 0 {
+			in.Priority = 1
+		}
+		in.Id = lastId + 1
+		in.Time = time.Now().Unix()
+		mementos = append(mementos, &in)
+		if err := writeMementos(mementos); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.mementos = mementos
+		writeJSON(w, http.StatusCreated, in)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleMemento serves DELETE and PATCH /api/mementos/{id}, both requiring
+// --token if set.
+func (s *mementoServer) handleMemento(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/mementos/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("Invalid memento id: %s", idStr))
+		return
+	}
+
+	if !s.authorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Hold the write lock across the whole read-modify-write-reload cycle
+	// so a concurrent DELETE and PATCH for the same (or a neighboring) id
+	// can't clobber each other's changes.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mementos, err := readMementos()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	n, ok := findMementoById(mementos, id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		mementos = append(mementos[:n], mementos[n+1:]...)
+	case http.MethodPatch:
+		var patch struct {
+			Priority *int    `json:"priority"`
+			Msg      *string `json:"msg"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		if patch.Priority != nil {
+			mementos[n].Priority = *patch.Priority
+		}
+		if patch.Msg != nil {
+			mementos[n].Msg = *patch.Msg
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := writeMementos(mementos); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.mementos = mementos
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
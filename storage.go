@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Storage is the backend abstraction behind mementor's data file. It lets a
+// user point `-r` (or MEMENTOR_REPOSITORY) at whatever medium they keep
+// their mementos in, rather than hard-coding a single local JSON file.
+type Storage interface {
+	// Load returns all mementos currently in the repository.
+	Load() ([]*Memento, error)
+	// Save persists the full list of mementos, replacing whatever was
+	// there before.
+	Save(mementos []*Memento) error
+	// Lock prevents concurrent writers from stomping on each other.
+	// Callers must pair every Lock with an Unlock.
+	Lock() error
+	// Unlock releases a lock taken by Lock.
+	Unlock() error
+}
+
+// openStorage parses a repository URI of the form
+// `file:///path/to/mementos.json`, `sqlite:///path/to/mementos.db` or
+// `s3://bucket/key` and returns the matching Storage implementation. A bare
+// path with no scheme is treated as a file repository for convenience.
+func openStorage(repository string) (Storage, error) {
+	u, err := url.Parse(repository)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid repository %q: %s", repository, err)
+	}
+
+	switch u.Scheme {
+	case "", "file":
+		return newFileStorage(u.Path), nil
+	case "sqlite":
+		return newSQLiteStorage(u.Path)
+	case "s3":
+		return newS3Storage(u.Host, strings.TrimPrefix(u.Path, "/"))
+	default:
+		return nil, fmt.Errorf("Unsupported repository scheme: %q", u.Scheme)
+	}
+}
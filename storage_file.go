@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStorage keeps all mementos in a single JSON file on the local disk.
+// This is the original mementor storage format.
+type FileStorage struct {
+	path string
+	mu   sync.Mutex
+}
+
+func newFileStorage(path string) *FileStorage {
+	return &FileStorage{path: path}
+}
+
+// Load parses and returns the mementos stored in the backing file. If the
+// file does not exist yet, it is created empty first. A file that starts
+// with encryptedMagic is transparently decrypted with the master key from
+// the `.keys` sidecar, prompting for the password if it hasn't been
+// supplied yet.
+func (s *FileStorage) Load() ([]*Memento, error) {
+	if _, err := os.Stat(s.path); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if _, err := s.createFile(); err != nil {
+			return nil, err
+		}
+		pr.info("%s was be created", s.path)
+	}
+
+	logger.Debugf("opening %s", s.path)
+	raw, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := raw
+	if bytes.HasPrefix(raw, []byte(encryptedMagic)) {
+		payload, err = s.decrypt(raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var mementos []*Memento
+	if err := json.Unmarshal(payload, &mementos); err != nil && len(payload) > 0 {
+		return nil, err
+	}
+	return mementos, nil
+}
+
+// Save writes mementos into the backing file as a JSON string, truncating
+// whatever was there before. For an encrypted repository the payload is
+// sealed with the master key before being written.
+func (s *FileStorage) Save(mementos []*Memento) error {
+	out, err := json.Marshal(mementos)
+	if err != nil {
+		return err
+	}
+
+	if isEncryptedRepository(s.path) {
+		out, err = s.encrypt(out)
+		if err != nil {
+			return err
+		}
+	}
+
+	file, err := s.createFile()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	written, err := file.Write(out)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d bytes written\n", written)
+	return nil
+}
+
+// decrypt prompts for the repository password (if not already cached) and
+// returns the decrypted JSON payload for raw.
+func (s *FileStorage) decrypt(raw []byte) ([]byte, error) {
+	k, err := loadKeys(s.path)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := password()
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := openMasterKey(pw, k)
+	if err != nil {
+		return nil, err
+	}
+	return decryptPayload(masterKey, raw)
+}
+
+// encrypt seals plaintext under this repository's master key.
+func (s *FileStorage) encrypt(plaintext []byte) ([]byte, error) {
+	k, err := loadKeys(s.path)
+	if err != nil {
+		return nil, err
+	}
+	pw, err := password()
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := openMasterKey(pw, k)
+	if err != nil {
+		return nil, err
+	}
+	return encryptPayload(masterKey, plaintext)
+}
+
+// Vacuum is a no-op for file repositories: Save always rewrites the whole
+// file from scratch, so every write is already compacted and there is
+// nothing left behind by `forget` to reclaim. It exists so `forget --prune`
+// doesn't silently skip file:// repositories.
+func (s *FileStorage) Vacuum() error {
+	return nil
+}
+
+// Lock takes the in-process mutex guarding this file. mementor is a CLI
+// tool invoked once per process, so a process-local lock is enough to keep
+// concurrent subcommands within this binary from racing.
+func (s *FileStorage) Lock() error {
+	s.mu.Lock()
+	return nil
+}
+
+// Unlock releases the lock taken by Lock.
+func (s *FileStorage) Unlock() error {
+	s.mu.Unlock()
+	return nil
+}
+
+// createFile creates an empty file or truncates an existing one, creating
+// the parent directory first if necessary.
+func (s *FileStorage) createFile() (file *os.File, err error) {
+	dir := filepath.Dir(s.path)
+	if _, err = os.Stat(dir); err != nil {
+		fmt.Printf("Creating directory %s\n", dir)
+		err = os.MkdirAll(dir, os.ModeDir|0700)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create directory for the data file at %s.\n%s", dir, err)
+		}
+	}
+	file, err = os.Create(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
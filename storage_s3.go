@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// S3Storage stores the whole mementos list as a single JSON object under a
+// key in an S3 bucket, so a user can share one memento file across
+// machines. Credentials and region come from the usual AWS environment
+// variables / shared config, same as any other AWS SDK tool.
+type S3Storage struct {
+	bucket string
+	key    string
+	client *s3.S3
+	mu     sync.Mutex
+}
+
+func newS3Storage(bucket, key string) (*S3Storage, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	return &S3Storage{
+		bucket: bucket,
+		key:    key,
+		client: s3.New(sess),
+	}, nil
+}
+
+// Load fetches and decodes the mementos object. A missing object is treated
+// as an empty repository.
+func (s *S3Storage) Load() ([]*Memento, error) {
+	out, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	body, err := ioutil.ReadAll(out.Body)
+	if err != nil {
+		return nil, err
+	}
+	var mementos []*Memento
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &mementos); err != nil {
+			return nil, err
+		}
+	}
+	return mementos, nil
+}
+
+// Save marshals mementos and overwrites the S3 object.
+func (s *S3Storage) Save(mementos []*Memento) error {
+	body, err := json.Marshal(mementos)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+		Body:   bytes.NewReader(body),
+	})
+	return err
+}
+
+// Lock takes the in-process mutex guarding this client. S3 has no native
+// locking primitive, so concurrent use across machines is still the user's
+// responsibility.
+func (s *S3Storage) Lock() error {
+	s.mu.Lock()
+	return nil
+}
+
+// Unlock releases the lock taken by Lock.
+func (s *S3Storage) Unlock() error {
+	s.mu.Unlock()
+	return nil
+}
+
+func isNotFound(err error) bool {
+	type awsError interface {
+		Code() string
+	}
+	if aerr, ok := err.(awsError); ok {
+		return aerr.Code() == s3.ErrCodeNoSuchKey
+	}
+	return false
+}
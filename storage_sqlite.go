@@ -0,0 +1,107 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteStorage keeps mementos one-row-per-memento in a SQLite database,
+// indexed by Id and Priority so weighted fetches over large stores stay
+// cheap.
+type SQLiteStorage struct {
+	db *sql.DB
+	mu sync.Mutex
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS mementos (
+	id       INTEGER PRIMARY KEY,
+	msg      TEXT NOT NULL,
+	time     INTEGER NOT NULL,
+	priority INTEGER NOT NULL,
+	tags     TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_mementos_priority ON mementos (priority);
+`
+
+func newSQLiteStorage(path string) (*SQLiteStorage, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStorage{db: db}, nil
+}
+
+// Load returns all mementos, ordered by Id ascending like the file backend.
+func (s *SQLiteStorage) Load() ([]*Memento, error) {
+	rows, err := s.db.Query("SELECT id, msg, time, priority, tags FROM mementos ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mementos []*Memento
+	for rows.Next() {
+		m := &Memento{}
+		var tags string
+		if err := rows.Scan(&m.Id, &m.Msg, &m.Time, &m.Priority, &tags); err != nil {
+			return nil, err
+		}
+		if tags != "" {
+			m.Tags = strings.Split(tags, ",")
+		}
+		mementos = append(mementos, m)
+	}
+	return mementos, rows.Err()
+}
+
+// Save replaces the whole mementos table with the given slice.
+func (s *SQLiteStorage) Save(mementos []*Memento) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM mementos"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	stmt, err := tx.Prepare("INSERT INTO mementos (id, msg, time, priority, tags) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, m := range mementos {
+		if _, err := stmt.Exec(m.Id, m.Msg, m.Time, m.Priority, strings.Join(m.Tags, ",")); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Vacuum reclaims space left behind by deleted rows, used by
+// `mementor forget --prune`.
+func (s *SQLiteStorage) Vacuum() error {
+	_, err := s.db.Exec("VACUUM")
+	return err
+}
+
+// Lock takes the in-process mutex guarding this database handle.
+func (s *SQLiteStorage) Lock() error {
+	s.mu.Lock()
+	return nil
+}
+
+// Unlock releases the lock taken by Lock.
+func (s *SQLiteStorage) Unlock() error {
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tagFlags and notTagFlags back --tag/--not-tag on list and fetch, set up as
+// repeatable cobra flags in cli.go.
+var (
+	tagFlags    []string
+	notTagFlags []string
+)
+
+// hasTag reports whether m carries tag.
+func hasTag(m *Memento, tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// filterByTags narrows mementos down to those matching every --tag and none
+// of the --not-tag values.
+func filterByTags(mementos []*Memento) []*Memento {
+	if len(tagFlags) == 0 && len(notTagFlags) == 0 {
+		return mementos
+	}
+	var filtered []*Memento
+	for _, m := range mementos {
+		ok := true
+		for _, tag := range tagFlags {
+			if !hasTag(m, tag) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			for _, tag := range notTagFlags {
+				if hasTag(m, tag) {
+					ok = false
+					break
+				}
+			}
+		}
+		if ok {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// splitTags pulls "+tag" tokens out of words, returning the remaining
+// message words and the collected tags (without their "+" prefix).
+func splitTags(words []string) (remaining []string, tags []string) {
+	for _, w := range words {
+		if strings.HasPrefix(w, "+") && len(w) > 1 {
+			tags = append(tags, w[1:])
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	return remaining, tags
+}
+
+// tagCmd implements `mementor tag <id> +new -- -old`: tokens prefixed with
+// "+" are added, tokens prefixed with "-" are removed. "-" tokens only reach
+// args when given after "--"; before it, cobra parses them as flags.
+func tagCmd(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("Usage: mementor tag <id> +newtag -- -oldtag")
+	}
+	id, err := parseId(args)
+	if err != nil {
+		return err
+	}
+
+	mementos, err := readMementos()
+	if err != nil {
+		return err
+	}
+	n, ok := findMementoById(mementos, id)
+	if !ok {
+		return fmt.Errorf("Memento %d does not exist", id)
+	}
+	m := mementos[n]
+
+	for _, tok := range args[1:] {
+		switch {
+		case strings.HasPrefix(tok, "+") && len(tok) > 1:
+			tag := tok[1:]
+			if !hasTag(m, tag) {
+				m.Tags = append(m.Tags, tag)
+			}
+		case strings.HasPrefix(tok, "-") && len(tok) > 1:
+			tag := tok[1:]
+			var kept []string
+			for _, t := range m.Tags {
+				if t != tag {
+					kept = append(kept, t)
+				}
+			}
+			m.Tags = kept
+		default:
+			return fmt.Errorf("Invalid tag token: %s (use +tag or -tag)", tok)
+		}
+	}
+
+	return writeMementos(mementos)
+}